@@ -28,48 +28,368 @@ const (
 	brokerQueueSubsystem = "broker"
 	queueLatencyKey      = "queue_duration_seconds"
 	addsKey              = "queue_adds"
+	depthKey             = "queue_depth"
+	workDurationKey      = "work_duration_seconds"
+	unfinishedWorkKey    = "unfinished_work_seconds"
+	longestRunningKey    = "longest_running_processor_seconds"
+	retriesKey           = "queue_retries_total"
+	inFlightKey          = "queue_in_flight_events"
+	addsByKindKey        = "queue_adds_by_kind"
+	durationByKindKey    = "queue_duration_seconds_by_kind"
+
+	// subscriberSubsystem groups the per-subscriber fan-out metrics, which track health of
+	// individual consumers rather than the shared queue itself.
+	subscriberSubsystem   = "broker_subscriber"
+	subscriberLagKey      = "lag_events"
+	subscriberDeliveryKey = "delivery_duration_seconds"
+	subscriberDroppedKey  = "dropped_total"
+
+	// kindOther is the label value used for events whose resource kind is either unknown
+	// or not in allowedKinds, so that per-kind metrics stay bounded regardless of how many
+	// distinct kinds flow through the broker.
+	kindOther = "other"
+
+	// latencySummaryMaxAge and latencySummaryAgeBuckets bound the latency summary to a
+	// sliding window so high-cardinality broker names don't retain unbounded memory.
+	latencySummaryMaxAge     = 10 * time.Minute
+	latencySummaryAgeBuckets = 5
 
 	addLabel    = "Add"
 	updateLabel = "Update"
 	deleteLabel = "Delete"
+
+	// unfinishedWorkUpdatePeriod is how often the unfinished work and longest running
+	// processor gauges are refreshed.
+	unfinishedWorkUpdatePeriod = 500 * time.Millisecond
+
+	// staleProcessingEntryAge bounds how long an entry can sit in processingStartTimes or
+	// subscriberInFlight before it's treated as abandoned and reaped.
+	staleProcessingEntryAge = 10 * time.Minute
 )
 
-var (
-	// latency is a prometheus metric which keeps track of the duration
-	// of sending events from collectors to the message broker.
-	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Subsystem: brokerQueueSubsystem,
-		Name:      queueLatencyKey,
-		Help:      "How long in seconds an event stays in the queue before being requested.",
-		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+// QueueMetricsProvider constructs the core workqueue-style metrics every broker needs,
+// independent of any per-kind or per-subscriber labeling.
+type QueueMetricsProvider interface {
+	NewLatencyMetric(name string) *prometheus.SummaryVec
+	NewAddsMetric(name string) *prometheus.CounterVec
+	NewDepthMetric(name string) *prometheus.GaugeVec
+	NewWorkDurationMetric(name string) *prometheus.HistogramVec
+	NewUnfinishedWorkSecondsMetric(name string) *prometheus.GaugeVec
+	NewLongestRunningProcessorSecondsMetric(name string) *prometheus.GaugeVec
+	NewRetriesMetric(name string) *prometheus.CounterVec
+	NewInFlightMetric(name string) *prometheus.GaugeVec
+}
+
+// KindMetricsProvider constructs the metrics broken down by Kubernetes resource kind.
+type KindMetricsProvider interface {
+	NewAddsByKindMetric(name string) *prometheus.CounterVec
+	NewDurationByKindMetric(name string) *prometheus.HistogramVec
+}
+
+// SubscriberMetricsProvider constructs the per-subscriber fan-out metrics.
+type SubscriberMetricsProvider interface {
+	NewSubscriberLagMetric(name string) *prometheus.GaugeVec
+	NewSubscriberDeliveryDurationMetric(name string) *prometheus.HistogramVec
+	NewSubscriberDroppedMetric(name string) *prometheus.CounterVec
+}
+
+// MetricsProvider creates and registers the prometheus metrics backing the broker.
+// It exists so that hosts embedding the broker can supply their own prometheus.Registerer
+// instead of being forced onto the controller-runtime global registry, which panics on
+// double-registration when the broker is created more than once against the same registry.
+// It's composed from the smaller interfaces above so that a new metric group doesn't force
+// every existing implementer to grow new methods.
+type MetricsProvider interface {
+	QueueMetricsProvider
+	KindMetricsProvider
+	SubscriberMetricsProvider
+}
+
+// allowedKinds bounds the cardinality of the per-kind metrics below. Kinds outside this
+// list, or events that don't report a kind at all, are folded into the kindOther bucket.
+var allowedKinds = map[string]struct{}{
+	"Pod":        {},
+	"Service":    {},
+	"Endpoints":  {},
+	"Namespace":  {},
+	"Deployment": {},
+}
+
+// kindLabeler is implemented by events.Event values that can report the Kubernetes
+// resource kind they carry. events.Event itself doesn't require Kind() yet, so
+// implementations that don't add it fall back to kindOther instead of failing.
+type kindLabeler interface {
+	Kind() string
+}
+
+// kindLabel returns the allow-listed resource kind for evt, or kindOther if evt doesn't
+// implement kindLabeler or reports a kind outside allowedKinds.
+func kindLabel(evt interface{}) string {
+	kl, ok := evt.(kindLabeler)
+	if !ok {
+		return kindOther
+	}
+
+	if _, allowed := allowedKinds[kl.Kind()]; !allowed {
+		return kindOther
+	}
+
+	return kl.Kind()
+}
+
+// registererMetricsProvider is the MetricsProvider the broker uses. It registers every metric
+// against the given prometheus.Registerer: ctrlmetrics.Registry by default, or the one passed
+// to SetProvider.
+type registererMetricsProvider struct {
+	reg prometheus.Registerer
+}
+
+func (p registererMetricsProvider) NewLatencyMetric(name string) *prometheus.SummaryVec {
+	m := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Subsystem:  brokerQueueSubsystem,
+		Name:       name,
+		Help:       "How long in seconds an event stays in the queue before being requested.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		MaxAge:     latencySummaryMaxAge,
+		AgeBuckets: latencySummaryAgeBuckets,
 	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
 
-	adds = prometheus.NewCounterVec(prometheus.CounterOpts{
+func (p registererMetricsProvider) NewAddsMetric(name string) *prometheus.CounterVec {
+	m := prometheus.NewCounterVec(prometheus.CounterOpts{
 		Subsystem: brokerQueueSubsystem,
-		Name:      addsKey,
+		Name:      name,
 		Help:      "Total number of events handled by the queue",
 	}, []string{"name", "type"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewDepthMetric(name string) *prometheus.GaugeVec {
+	m := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "Current number of events waiting in the queue to be delivered.",
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewWorkDurationMetric(name string) *prometheus.HistogramVec {
+	m := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "How long in seconds it takes a consumer to process an event once it has been popped from the queue.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) *prometheus.GaugeVec {
+	m := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help: "How many seconds of work has been done that is in progress and hasn't been observed by work_duration_seconds. " +
+			"Large values indicate stuck consumers.",
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) *prometheus.GaugeVec {
+	m := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "How many seconds the longest currently-running consumer has been processing a single event.",
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewRetriesMetric(name string) *prometheus.CounterVec {
+	m := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "Total number of events requeued after a failed delivery.",
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewInFlightMetric(name string) *prometheus.GaugeVec {
+	m := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "Current number of events popped from the queue and still being processed by a consumer.",
+	}, []string{"name"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewAddsByKindMetric(name string) *prometheus.CounterVec {
+	m := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "Total number of events handled by the queue, broken down by Kubernetes resource kind.",
+	}, []string{"name", "kind"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewDurationByKindMetric(name string) *prometheus.HistogramVec {
+	m := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: brokerQueueSubsystem,
+		Name:      name,
+		Help:      "How long in seconds an event stays in the queue before being requested, broken down by Kubernetes resource kind.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name", "kind"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewSubscriberLagMetric(name string) *prometheus.GaugeVec {
+	m := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: subscriberSubsystem,
+		Name:      name,
+		Help:      "Number of events produced by the broker but not yet delivered to this subscriber.",
+	}, []string{"name", "subscriber", "kind"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewSubscriberDeliveryDurationMetric(name string) *prometheus.HistogramVec {
+	m := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: subscriberSubsystem,
+		Name:      name,
+		Help:      "How long in seconds it takes the broker to deliver an event to this subscriber.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name", "subscriber", "kind"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+func (p registererMetricsProvider) NewSubscriberDroppedMetric(name string) *prometheus.CounterVec {
+	m := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: subscriberSubsystem,
+		Name:      name,
+		Help:      "Total number of events dropped for this subscriber because it could not keep up with the broker.",
+	}, []string{"name", "subscriber", "kind"})
+	p.reg.MustRegister(m)
+	return m
+}
+
+var (
+	providerMu      sync.Mutex
+	provider        MetricsProvider = registererMetricsProvider{reg: ctrlmetrics.Registry}
+	initMetricsOnce sync.Once
+
+	latency                    *prometheus.SummaryVec
+	adds                       *prometheus.CounterVec
+	depth                      *prometheus.GaugeVec
+	workDuration               *prometheus.HistogramVec
+	unfinishedWork             *prometheus.GaugeVec
+	longestRunningProcessor    *prometheus.GaugeVec
+	retries                    *prometheus.CounterVec
+	inFlight                   *prometheus.GaugeVec
+	addsByKind                 *prometheus.CounterVec
+	durationByKind             *prometheus.HistogramVec
+	subscriberLag              *prometheus.GaugeVec
+	subscriberDeliveryDuration *prometheus.HistogramVec
+	subscriberDropped          *prometheus.CounterVec
+
+	// metricsInitialized is set once initMetrics has read provider, so that a SetProvider
+	// call arriving after the first broker was created is a documented no-op instead of a
+	// write racing with that read.
+	metricsInitialized bool
 )
 
-func init() {
-	// Register custom metrics with the global prometheus registry.
-	ctrlmetrics.Registry.MustRegister(latency)
-	ctrlmetrics.Registry.MustRegister(adds)
+// SetProvider installs reg as the prometheus.Registerer used to register the broker's
+// metrics, replacing the default controller-runtime registry. Each call made before the
+// first broker is created replaces the registerer, so the last such call wins; calls made
+// after that are a no-op.
+func SetProvider(reg prometheus.Registerer) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+
+	if metricsInitialized {
+		return
+	}
+
+	provider = registererMetricsProvider{reg: reg}
+}
+
+// initMetrics creates the package-level metric vectors from provider exactly once,
+// regardless of how many brokers are created.
+func initMetrics() {
+	initMetricsOnce.Do(func() {
+		providerMu.Lock()
+		p := provider
+		metricsInitialized = true
+		providerMu.Unlock()
+
+		latency = p.NewLatencyMetric(queueLatencyKey)
+		adds = p.NewAddsMetric(addsKey)
+		depth = p.NewDepthMetric(depthKey)
+		workDuration = p.NewWorkDurationMetric(workDurationKey)
+		unfinishedWork = p.NewUnfinishedWorkSecondsMetric(unfinishedWorkKey)
+		longestRunningProcessor = p.NewLongestRunningProcessorSecondsMetric(longestRunningKey)
+		retries = p.NewRetriesMetric(retriesKey)
+		inFlight = p.NewInFlightMetric(inFlightKey)
+		addsByKind = p.NewAddsByKindMetric(addsByKindKey)
+		durationByKind = p.NewDurationByKindMetric(durationByKindKey)
+		subscriberLag = p.NewSubscriberLagMetric(subscriberLagKey)
+		subscriberDeliveryDuration = p.NewSubscriberDeliveryDurationMetric(subscriberDeliveryKey)
+		subscriberDropped = p.NewSubscriberDroppedMetric(subscriberDroppedKey)
+	})
+}
+
+// subscriberEvent pairs the subscriber an event was fanned out to, and its resource kind,
+// with the time it was handed off, mirroring queuedEvent so delivery duration can be
+// computed directly instead of through a lookup table.
+type subscriberEvent struct {
+	id         uint64
+	subscriber string
+	kind       string
+	sentAt     time.Time
+}
+
+// queuedEvent pairs an event with the time it was sent to the queue so that receive can
+// compute latency directly, without keeping a separate timestamp lookup table around for
+// the lifetime of every enqueued event.
+type queuedEvent struct {
+	evt    events.Event
+	sentAt time.Time
 }
 
 // metrics holds the metrics related to queue. It tracks the number of produced events for each type of events.
-// Also tracks the latency of the queue.
+// Also tracks the latency of the queue, together with workqueue-style depth, retry and processing-time metrics.
 type metrics struct {
 	sync.Mutex
-	addCounter      prometheus.Counter
-	updateCounter   prometheus.Counter
-	deleteCounter   prometheus.Counter
-	latencyObserver prometheus.Observer
-	sentTimes       map[interface{}]time.Time
+	name                         string
+	addCounter                   prometheus.Counter
+	updateCounter                prometheus.Counter
+	deleteCounter                prometheus.Counter
+	latencyObserver              prometheus.Observer
+	depthGauge                   prometheus.Gauge
+	workDurationObserver         prometheus.Observer
+	unfinishedWorkSecondsGauge   prometheus.Gauge
+	longestRunningProcessorGauge prometheus.Gauge
+	retriesCounter               prometheus.Counter
+	inFlightGauge                prometheus.Gauge
+	processingStartTimes         map[interface{}]time.Time
+	subscriberSeq                uint64
+	subscriberInFlight           map[uint64]subscriberEvent
+	stopCh                       chan struct{}
+	stopOnce                     sync.Once
 }
 
 // newMetrics returns a new ChannelMetrics ready to be used.
 func newMetrics(name string) *metrics {
+	initMetrics()
+
 	// Initialize counters.
 	addCounter := adds.WithLabelValues(name, addLabel)
 	addCounter.Add(0)
@@ -79,21 +399,50 @@ func newMetrics(name string) *metrics {
 	deleteCounter.Add(0)
 	lattencyObserver := latency.WithLabelValues(name)
 
-	return &metrics{
-		Mutex:           sync.Mutex{},
-		addCounter:      addCounter,
-		updateCounter:   updateCounter,
-		deleteCounter:   deleteCounter,
-		latencyObserver: lattencyObserver,
-		sentTimes:       make(map[interface{}]time.Time),
+	m := &metrics{
+		Mutex:                        sync.Mutex{},
+		name:                         name,
+		addCounter:                   addCounter,
+		updateCounter:                updateCounter,
+		deleteCounter:                deleteCounter,
+		latencyObserver:              lattencyObserver,
+		depthGauge:                   depth.WithLabelValues(name),
+		workDurationObserver:         workDuration.WithLabelValues(name),
+		unfinishedWorkSecondsGauge:   unfinishedWork.WithLabelValues(name),
+		longestRunningProcessorGauge: longestRunningProcessor.WithLabelValues(name),
+		retriesCounter:               retries.WithLabelValues(name),
+		inFlightGauge:                inFlight.WithLabelValues(name),
+		processingStartTimes:         make(map[interface{}]time.Time),
+		subscriberInFlight:           make(map[uint64]subscriberEvent),
+		stopCh:                       make(chan struct{}),
 	}
+
+	go m.updateUnfinishedWorkLoop()
+
+	return m
 }
 
-// send to be called before adding the item to the queue.
-func (m *metrics) send(evt events.Event) {
+// Close stops the background goroutine refreshing the unfinished_work_seconds and
+// longest_running_processor_seconds gauges. Call it once the metrics instance is no longer
+// in use. Close is safe to call more than once.
+func (m *metrics) Close() {
 	if m == nil {
 		return
 	}
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
+// send to be called before adding the item to the queue. It returns the queuedEvent that
+// must be pushed onto the queue in place of evt, so that receive can later compute latency
+// from the timestamp carried alongside it instead of a separate lookup table.
+func (m *metrics) send(evt events.Event) queuedEvent {
+	entry := queuedEvent{evt: evt, sentAt: time.Now()}
+
+	if m == nil {
+		return entry
+	}
 	m.Lock()
 	defer m.Unlock()
 
@@ -106,21 +455,165 @@ func (m *metrics) send(evt events.Event) {
 		m.deleteCounter.Inc()
 	}
 
-	if _, ok := m.sentTimes[evt]; !ok {
-		m.sentTimes[evt] = time.Now()
+	m.depthGauge.Inc()
+	addsByKind.WithLabelValues(m.name, kindLabel(evt)).Inc()
+
+	return entry
+}
+
+// receive to be called after the item has been popped from the queue and before it is
+// handed off to the consumer callback.
+func (m *metrics) receive(entry queuedEvent) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	duration := time.Since(entry.sentAt).Seconds()
+	m.latencyObserver.Observe(duration)
+	durationByKind.WithLabelValues(m.name, kindLabel(entry.evt)).Observe(duration)
+	m.depthGauge.Dec()
+	m.inFlightGauge.Inc()
+	m.processingStartTimes[entry.evt] = time.Now()
+}
+
+// done to be called once the consumer callback has finished processing evt.
+func (m *metrics) done(evt interface{}) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+
+	if startTime, ok := m.processingStartTimes[evt]; ok {
+		m.workDurationObserver.Observe(time.Since(startTime).Seconds())
+		delete(m.processingStartTimes, evt)
+		m.inFlightGauge.Dec()
+	}
+}
+
+// retry to be called whenever an event is requeued after a failed delivery.
+func (m *metrics) retry() {
+	if m == nil {
+		return
+	}
+	m.retriesCounter.Inc()
+}
+
+// sendToSubscriber to be called when the broker's fan-out loop hands evt off to subscriber's
+// delivery channel. The returned subscriberEvent must be passed to receivedBySubscriber or
+// droppedBySubscriber once the subscriber has taken delivery (or been dropped), so the lag
+// gauge and delivery histogram stay accurate.
+func (m *metrics) sendToSubscriber(subscriber string, evt events.Event) subscriberEvent {
+	entry := subscriberEvent{subscriber: subscriber, kind: kindLabel(evt), sentAt: time.Now()}
+
+	if m == nil {
+		return entry
 	}
+
+	m.Lock()
+	m.subscriberSeq++
+	entry.id = m.subscriberSeq
+	m.subscriberInFlight[entry.id] = entry
+	m.Unlock()
+
+	subscriberLag.WithLabelValues(m.name, subscriber, entry.kind).Inc()
+
+	return entry
 }
 
-// receive to be called after the item has been pooped from the queue.
-func (m *metrics) receive(evt interface{}) {
+// receivedBySubscriber to be called once entry.subscriber has taken delivery of entry's event.
+func (m *metrics) receivedBySubscriber(entry subscriberEvent) {
 	if m == nil {
 		return
 	}
+
+	m.Lock()
+	delete(m.subscriberInFlight, entry.id)
+	m.Unlock()
+
+	subscriberLag.WithLabelValues(m.name, entry.subscriber, entry.kind).Dec()
+	subscriberDeliveryDuration.WithLabelValues(m.name, entry.subscriber, entry.kind).Observe(time.Since(entry.sentAt).Seconds())
+}
+
+// droppedBySubscriber to be called when entry's event could not be delivered to its subscriber
+// because it fell too far behind the rest of the broker's fan-out. The event was already
+// counted by sendToSubscriber, so its lag entry is cleared here instead of in receivedBySubscriber.
+func (m *metrics) droppedBySubscriber(entry subscriberEvent) {
+	if m == nil {
+		return
+	}
+
+	m.Lock()
+	delete(m.subscriberInFlight, entry.id)
+	m.Unlock()
+
+	subscriberLag.WithLabelValues(m.name, entry.subscriber, entry.kind).Dec()
+	subscriberDropped.WithLabelValues(m.name, entry.subscriber, entry.kind).Inc()
+}
+
+// updateUnfinishedWorkLoop periodically refreshes the unfinished_work_seconds and
+// longest_running_processor_seconds gauges from the in-flight processingStartTimes,
+// matching the approach used by k8s.io/client-go/util/workqueue. It exits once Close
+// is called, so it does not leak for the lifetime of the process.
+func (m *metrics) updateUnfinishedWorkLoop() {
+	ticker := time.NewTicker(unfinishedWorkUpdatePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.updateUnfinishedWork()
+			m.reapStaleSubscriberSends()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+func (m *metrics) updateUnfinishedWork() {
 	m.Lock()
 	defer m.Unlock()
 
-	if startTime, ok := m.sentTimes[evt]; ok {
-		m.latencyObserver.Observe(time.Since(startTime).Seconds())
-		delete(m.sentTimes, evt)
+	var unfinished, longest float64
+	now := time.Now()
+	for evt, startTime := range m.processingStartTimes {
+		age := now.Sub(startTime)
+		if age > staleProcessingEntryAge {
+			// done() was never called for this event: the consumer likely panicked or the
+			// subscription dropped before acking. Reap it so the map and the gauges below
+			// don't grow, or stay wrong, forever.
+			delete(m.processingStartTimes, evt)
+			m.inFlightGauge.Dec()
+			continue
+		}
+
+		d := age.Seconds()
+		unfinished += d
+		if d > longest {
+			longest = d
+		}
 	}
-}
\ No newline at end of file
+
+	m.unfinishedWorkSecondsGauge.Set(unfinished)
+	m.longestRunningProcessorGauge.Set(longest)
+}
+
+// reapStaleSubscriberSends clears subscriberLag entries left behind by a sendToSubscriber
+// that was never followed by receivedBySubscriber or droppedBySubscriber, e.g. because the
+// fan-out goroutine panicked or the subscriber channel was torn down mid-delivery. Same
+// abandoned-entry problem updateUnfinishedWork solves for processingStartTimes, applied to
+// subscriberInFlight so subscriberLag can't drift upward forever either.
+func (m *metrics) reapStaleSubscriberSends() {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	for id, entry := range m.subscriberInFlight {
+		if now.Sub(entry.sentAt) > staleProcessingEntryAge {
+			delete(m.subscriberInFlight, id)
+			subscriberLag.WithLabelValues(m.name, entry.subscriber, entry.kind).Dec()
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 The Falco Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/alacuku/k8s-metadata/internal/events"
+)
+
+func TestMetricsCloseStopsUpdateUnfinishedWorkLoop(t *testing.T) {
+	m := newMetrics(t.Name())
+
+	done := make(chan struct{})
+	go func() {
+		m.updateUnfinishedWorkLoop()
+		close(done)
+	}()
+
+	m.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("updateUnfinishedWorkLoop did not return after Close")
+	}
+}
+
+func TestMetricsCloseIsIdempotent(t *testing.T) {
+	m := newMetrics(t.Name())
+
+	m.Close()
+	m.Close()
+}
+
+type fakeKindEvent struct {
+	kind string
+}
+
+func (e fakeKindEvent) Kind() string { return e.kind }
+
+func (e fakeKindEvent) Type() events.EventType { return events.Added }
+
+// plainEvent is an events.Event that doesn't implement kindLabeler, representing any
+// production event type that hasn't been updated to report its Kind yet.
+type plainEvent struct{}
+
+func (plainEvent) Type() events.EventType { return events.Added }
+
+func TestKindLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  interface{}
+		want string
+	}{
+		{name: "allow-listed kind", evt: fakeKindEvent{kind: "Pod"}, want: "Pod"},
+		{name: "kind outside the allow-list", evt: fakeKindEvent{kind: "CustomResource"}, want: kindOther},
+		{name: "event without a Kind method", evt: "not a kindLabeler", want: kindOther},
+		{name: "events.Event without a Kind method", evt: plainEvent{}, want: kindOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kindLabel(tt.evt); got != tt.want {
+				t.Errorf("kindLabel(%v) = %q, want %q", tt.evt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUnfinishedWorkReapsStaleEntries(t *testing.T) {
+	m := newMetrics(t.Name())
+	defer m.Close()
+
+	m.Lock()
+	m.processingStartTimes["stale"] = time.Now().Add(-2 * staleProcessingEntryAge)
+	m.processingStartTimes["fresh"] = time.Now()
+	m.Unlock()
+
+	m.updateUnfinishedWork()
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.processingStartTimes["stale"]; ok {
+		t.Fatal("updateUnfinishedWork did not reap an entry older than staleProcessingEntryAge")
+	}
+	if _, ok := m.processingStartTimes["fresh"]; !ok {
+		t.Fatal("updateUnfinishedWork reaped an entry that was not stale")
+	}
+}
+
+func TestSendToSubscriberLabelsByKind(t *testing.T) {
+	m := newMetrics(t.Name())
+	defer m.Close()
+
+	evt := fakeKindEvent{kind: "Pod"}
+
+	entry := m.sendToSubscriber("subscriber-a", evt)
+
+	if entry.kind != kindLabel(evt) {
+		t.Fatalf("sendToSubscriber entry.kind = %q, want %q", entry.kind, kindLabel(evt))
+	}
+	if entry.subscriber != "subscriber-a" {
+		t.Fatalf("sendToSubscriber entry.subscriber = %q, want %q", entry.subscriber, "subscriber-a")
+	}
+
+	m.receivedBySubscriber(entry)
+
+	dropped := m.sendToSubscriber("subscriber-a", evt)
+	m.droppedBySubscriber(dropped)
+}
+
+func TestReapStaleSubscriberSendsClearsAbandonedLag(t *testing.T) {
+	m := newMetrics(t.Name())
+	defer m.Close()
+
+	acked := m.sendToSubscriber("subscriber-a", fakeKindEvent{kind: "Pod"})
+	m.receivedBySubscriber(acked)
+
+	abandoned := m.sendToSubscriber("subscriber-a", fakeKindEvent{kind: "Pod"})
+
+	m.Lock()
+	m.subscriberInFlight[abandoned.id] = subscriberEvent{
+		id:         abandoned.id,
+		subscriber: abandoned.subscriber,
+		kind:       abandoned.kind,
+		sentAt:     time.Now().Add(-2 * staleProcessingEntryAge),
+	}
+	m.Unlock()
+
+	m.reapStaleSubscriberSends()
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.subscriberInFlight[abandoned.id]; ok {
+		t.Fatal("reapStaleSubscriberSends did not reap a send older than staleProcessingEntryAge")
+	}
+}
+
+func TestSetProviderNoopAfterInit(t *testing.T) {
+	// Force metrics to be initialized, as the first newMetrics call would in production.
+	initMetrics()
+
+	before := provider
+
+	SetProvider(prometheus.NewRegistry())
+
+	if provider != before {
+		t.Fatal("SetProvider replaced the provider after metrics were already initialized")
+	}
+}